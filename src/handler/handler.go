@@ -3,9 +3,12 @@ package handler
 import (
 	"aad-auth-proxy/constants"
 	"aad-auth-proxy/contracts"
+	"aad-auth-proxy/telemetry"
+	"aad-auth-proxy/telemetry/httpconv"
 	"aad-auth-proxy/utils"
 	"context"
 	"errors"
+	"fmt"
 	"go.opentelemetry.io/otel/metric"
 	"net/http"
 	"net/http/httputil"
@@ -16,19 +19,30 @@ import (
 	"go.opentelemetry.io/otel"
 	"go.opentelemetry.io/otel/attribute"
 	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/propagation"
+	"go.opentelemetry.io/otel/trace"
 )
 
 // This manages token provider handler
 type Handler struct {
-	targetHost      string
-	proxy           *httputil.ReverseProxy
-	tokenProvider   contracts.ITokenProvider
-	configuration   utils.IConfiguration
-	overrideHeaders map[string]string
+	targetHost             string
+	proxy                  *httputil.ReverseProxy
+	tokenProvider          contracts.ITokenProvider
+	configuration          utils.IConfiguration
+	overrideHeaders        map[string]string
+	attributeConvention    string
+	instruments            *httpconv.Instruments
+	capturedRequestHeaders []string
+	sensitiveHeaders       []string
+	headerRedactionValue   string
+	legacyTraceIDHeader    string
 }
 
-// Creates a new handler
-func NewHandler(proxy *httputil.ReverseProxy, tokenProvider contracts.ITokenProvider, configuration utils.IConfiguration) (handler *Handler, err error) {
+// Creates a new handler. instruments must be the single *httpconv.Instruments
+// shared with CreateReverseProxy so the handler, director, and modifyResponse
+// paths all record to the same registered instruments instead of registering
+// duplicates against the global meter.
+func NewHandler(proxy *httputil.ReverseProxy, tokenProvider contracts.ITokenProvider, configuration utils.IConfiguration, instruments *httpconv.Instruments) (handler *Handler, err error) {
 	if proxy == nil {
 		return nil, errors.New("proxy cannot be nil")
 	}
@@ -41,49 +55,82 @@ func NewHandler(proxy *httputil.ReverseProxy, tokenProvider contracts.ITokenProv
 		return nil, errors.New("configuration cannot be nil")
 	}
 
+	if instruments == nil {
+		return nil, errors.New("instruments cannot be nil")
+	}
+
 	var overrideHeaders map[string]string = nil
 	additionalheaders := configuration.GetAdditionalHeaders()
 	if additionalheaders != nil && len(additionalheaders) > 0 {
 		overrideHeaders = additionalheaders
 	}
 
+	attributeConvention := configuration.GetOtelAttributeConvention()
+	if attributeConvention == "" {
+		attributeConvention = httpconv.ConventionLegacy
+	} else if !httpconv.ValidConvention(attributeConvention) {
+		return nil, fmt.Errorf("unknown OtelAttributeConvention %q, expected %q, %q, or %q", attributeConvention, httpconv.ConventionLegacy, httpconv.ConventionSemConv, httpconv.ConventionBoth)
+	}
+
+	headerRedactionValue := configuration.GetHeaderRedactionValue()
+	if headerRedactionValue == "" {
+		headerRedactionValue = defaultHeaderRedactionValue
+	}
+
 	return &Handler{
-		targetHost:      configuration.GetTargetHost(),
-		proxy:           proxy,
-		tokenProvider:   tokenProvider,
-		configuration:   configuration,
-		overrideHeaders: overrideHeaders,
+		targetHost:             configuration.GetTargetHost(),
+		proxy:                  proxy,
+		tokenProvider:          tokenProvider,
+		configuration:          configuration,
+		overrideHeaders:        overrideHeaders,
+		attributeConvention:    attributeConvention,
+		instruments:            instruments,
+		capturedRequestHeaders: configuration.GetCapturedRequestHeaders(),
+		sensitiveHeaders:       configuration.GetSensitiveHeaders(),
+		headerRedactionValue:   headerRedactionValue,
+		legacyTraceIDHeader:    configuration.GetLegacyTraceIDHeaderName(),
 	}, nil
 }
 
+// Default replacement value for headers that match the sensitive headers
+// list (e.g. Authorization, Cookie) when captured as span attributes.
+const defaultHeaderRedactionValue = "REDACTED"
+
 // Reverse proxy handler
 func (handler *Handler) ProxyRequest(w http.ResponseWriter, r *http.Request) {
+	// Extract inbound W3C tracecontext/baggage; if the request carries no
+	// traceparent, fall back to the configured legacy trace ID header so
+	// traces from CDN/edge layers still stitch together across the proxy.
+	parentCtx := otel.GetTextMapPropagator().Extract(r.Context(), propagation.HeaderCarrier(r.Header))
+	if !trace.SpanContextFromContext(parentCtx).IsValid() {
+		parentCtx = telemetry.ExtractLegacyTraceContext(parentCtx, r.Header, handler.legacyTraceIDHeader)
+	}
+
 	// Start tracing
-	ctx, span := otel.Tracer(constants.SERVICE_TELEMETRY_KEY).Start(r.Context(), "ProxyRequest")
+	ctx, span := otel.Tracer(constants.SERVICE_TELEMETRY_KEY).Start(parentCtx, "ProxyRequest")
 	defer span.End()
 
-	attributes := []attribute.KeyValue{
-		attribute.String("request.query_string", r.URL.RawQuery),
-		attribute.String("request.path", r.URL.Path),
-		attribute.String("request.method", r.Method),
+	attributes := httpconv.SpanRequestAttributes(r, handler.targetHost, handler.attributeConvention)
+	attributes = append(attributes,
 		attribute.Int64("request.content_length", r.ContentLength),
 		attribute.String("request.content_type", r.Header.Get("Content-Type")),
-		attribute.String("request.user_agent", r.Header.Get("user-Agent")),
 		attribute.String("request.content_encoding", r.Header.Get("Content-Encoding")),
-	}
+		attribute.String("request.query_string", r.URL.RawQuery),
+	)
+	attributes = append(attributes, httpconv.CapturedHeaderAttributes(
+		r.Header, handler.capturedRequestHeaders, handler.sensitiveHeaders, handler.headerRedactionValue, httpconv.DirectionRequest,
+	)...)
 
 	span.SetAttributes(attributes...)
 
 	err := handler.checkTokenProvider(ctx)
 	if err != nil {
-		// Metric attributes
-		metricAttributes := []attribute.KeyValue{
-			attribute.String("target_host", r.URL.Host),
-			attribute.String("method", r.Method),
-			attribute.String("path", r.URL.Path),
-			attribute.String("user_agent", r.Header.Get("User-Agent")),
-			attribute.Int("status_code", http.StatusServiceUnavailable),
-		}
+		// Metric attributes. requests_total is a legacy-named instrument, so
+		// its attributes always use the legacy convention regardless of the
+		// operator's attributeConvention toggle, which only governs trace
+		// attributes.
+		metricAttributes := httpconv.RequestAttributes(r, handler.targetHost, httpconv.ConventionLegacy)
+		metricAttributes = append(metricAttributes, httpconv.StatusAttributes(http.StatusServiceUnavailable, httpconv.ConventionLegacy)...)
 
 		// Record metrics
 		// requests_total{target_host, method, path, user_agent, status_code}
@@ -113,27 +160,33 @@ func (handler *Handler) ProxyRequest(w http.ResponseWriter, r *http.Request) {
 	startTime := time.Now()
 	defer func() {
 		// Extract duration and status_code
-		duration := time.Since(startTime).Milliseconds()
+		duration := time.Since(startTime)
 		status_code, err := strconv.ParseInt(w.Header().Get("Status-Code"), 10, 32)
 		if err != nil {
-			log.Errorln("Failed to parse status code, assuming status code 0")
+			log.WithContext(ctx).Errorln("Failed to parse status code, assuming status code 0")
 			status_code = 0
 		}
+
+		// Each instrument's attributes always use the convention matching
+		// its own name, independent of the operator's attributeConvention
+		// toggle, so legacy dashboards and semconv dashboards both keep
+		// their expected label sets.
+		legacyAttributes := httpconv.RequestAttributes(r, handler.targetHost, httpconv.ConventionLegacy)
+		legacyAttributes = append(legacyAttributes, httpconv.StatusAttributes(int(status_code), httpconv.ConventionLegacy)...)
+		legacyOptions := metric.WithAttributes(legacyAttributes...)
+
 		// Record metrics
-		// request_duration_milliseconds{target_host, method, path, user_agent, status_code}
+		// request_duration_milliseconds{target_host, method, path, user_agent, status_code} (legacy)
 		requestDurationMeter := otel.Meter(constants.SERVICE_TELEMETRY_KEY)
-		requestDurationIntrument, err := requestDurationMeter.Int64Histogram(constants.METRIC_REQUEST_DURATION_MILLISECONDS)
-		if err == nil {
-			metricAttributes := []attribute.KeyValue{
-				attribute.String("target_host", handler.targetHost),
-				attribute.String("method", r.Method),
-				attribute.String("path", r.URL.Path),
-				attribute.String("user_agent", r.Header.Get("User-Agent")),
-				attribute.Int("status_code", int(status_code)),
-			}
-			options := metric.WithAttributes(metricAttributes...)
-			requestDurationIntrument.Record(ctx, duration, options)
+		requestDurationIntrument, legacyErr := requestDurationMeter.Int64Histogram(constants.METRIC_REQUEST_DURATION_MILLISECONDS)
+		if legacyErr == nil {
+			requestDurationIntrument.Record(ctx, duration.Milliseconds(), legacyOptions)
 		}
+
+		// http.server.request.duration{...} (semconv)
+		semConvAttributes := httpconv.RequestAttributes(r, handler.targetHost, httpconv.ConventionSemConv)
+		semConvAttributes = append(semConvAttributes, httpconv.StatusAttributes(int(status_code), httpconv.ConventionSemConv)...)
+		handler.instruments.RequestDuration.Record(ctx, duration.Seconds(), metric.WithAttributes(semConvAttributes...))
 	}()
 
 	// Handle request
@@ -163,7 +216,7 @@ func (handler *Handler) checkTokenProvider(ctx context.Context) error {
 		token, err := handler.tokenProvider.GetAccessToken()
 		if len(token) == 0 || err != nil {
 			// Start tracing
-			_, span := otel.Tracer(constants.SERVICE_TELEMETRY_KEY).Start(ctx, "checkTokenProvider")
+			spanCtx, span := otel.Tracer(constants.SERVICE_TELEMETRY_KEY).Start(ctx, "checkTokenProvider")
 			defer span.End()
 
 			// If we run into a case where we received empty token without any errors
@@ -175,7 +228,7 @@ func (handler *Handler) checkTokenProvider(ctx context.Context) error {
 			span.RecordError(err)
 			span.SetStatus(codes.Error, "failed to forward request")
 
-			log.Errorln("failed to forward request", err)
+			log.WithContext(spanCtx).Errorln("failed to forward request", err)
 
 			return err
 		}
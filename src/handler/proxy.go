@@ -3,6 +3,7 @@ package handler
 import (
 	"aad-auth-proxy/constants"
 	"aad-auth-proxy/contracts"
+	"aad-auth-proxy/telemetry/httpconv"
 	"aad-auth-proxy/utils"
 	"bytes"
 	"context"
@@ -19,27 +20,54 @@ import (
 	"go.opentelemetry.io/otel/attribute"
 	"go.opentelemetry.io/otel/codes"
 	"go.opentelemetry.io/otel/metric"
+	"go.opentelemetry.io/otel/propagation"
 )
 
-// Creates proxy for incoming requests
-func CreateReverseProxy(targetHost string, tokenProvider contracts.ITokenProvider) (*httputil.ReverseProxy, error) {
+// Creates proxy for incoming requests. instruments must be the single
+// *httpconv.Instruments shared with NewHandler so the director, modifyResponse,
+// and handler paths all record to the same registered instruments instead of
+// registering duplicates against the global meter. The error path records
+// only the legacy requests_total counter.
+func CreateReverseProxy(targetHost string, tokenProvider contracts.ITokenProvider, configuration utils.IConfiguration, instruments *httpconv.Instruments) (*httputil.ReverseProxy, error) {
+	if instruments == nil {
+		return nil, errors.New("instruments cannot be nil")
+	}
+
 	url, err := url.Parse(targetHost)
 	if err != nil {
 		return nil, err
 	}
 	proxy := httputil.NewSingleHostReverseProxy(url)
 
+	attributeConvention := configuration.GetOtelAttributeConvention()
+	if attributeConvention == "" {
+		attributeConvention = httpconv.ConventionLegacy
+	} else if !httpconv.ValidConvention(attributeConvention) {
+		return nil, fmt.Errorf("unknown OtelAttributeConvention %q, expected %q, %q, or %q", attributeConvention, httpconv.ConventionLegacy, httpconv.ConventionSemConv, httpconv.ConventionBoth)
+	}
+
+	capturedResponseHeaders := configuration.GetCapturedResponseHeaders()
+	sensitiveHeaders := configuration.GetSensitiveHeaders()
+	headerRedactionValue := configuration.GetHeaderRedactionValue()
+	if headerRedactionValue == "" {
+		headerRedactionValue = defaultHeaderRedactionValue
+	}
+
 	proxy.Director = func(request *http.Request) {
-		modifyRequest(request, targetHost, tokenProvider)
+		modifyRequest(request, targetHost, tokenProvider, instruments)
+	}
+	proxy.ErrorHandler = func(response http.ResponseWriter, request *http.Request, response_err error) {
+		handleError(response, request, response_err, attributeConvention)
+	}
+	proxy.ModifyResponse = func(response *http.Response) error {
+		return modifyResponse(response, attributeConvention, instruments, capturedResponseHeaders, sensitiveHeaders, headerRedactionValue)
 	}
-	proxy.ErrorHandler = handleError
-	proxy.ModifyResponse = modifyResponse
 
 	return proxy, nil
 }
 
 // This modifies incoming requests and changes host to targetHost
-func modifyRequest(request *http.Request, targetHost string, tokenProvider contracts.ITokenProvider) {
+func modifyRequest(request *http.Request, targetHost string, tokenProvider contracts.ITokenProvider, instruments *httpconv.Instruments) {
 	ctx, span := otel.Tracer(constants.SERVICE_TELEMETRY_KEY).Start(request.Context(), "modifyRequest")
 	defer span.End()
 
@@ -52,61 +80,66 @@ func modifyRequest(request *http.Request, targetHost string, tokenProvider contr
 	request.URL.Host = targetHost
 	request.Host = targetHost
 
-	// Record metrics
-	// request_bytes_total{target_host, method, path, user_agent}
-	metricAttributes := []attribute.KeyValue{
-		attribute.String("target_host", request.URL.Host),
-		attribute.String("method", request.Method),
-		attribute.String("path", request.URL.Path),
-		attribute.String("user_agent", request.Header.Get(constants.HEADER_USER_AGENT)),
-	}
+	// Forward the span context and baggage to the upstream so distributed
+	// traces continue past the proxy.
+	otel.GetTextMapPropagator().Inject(ctx, propagation.HeaderCarrier(request.Header))
+
+	// Record metrics. Each instrument's attributes always use the
+	// convention matching its own name, independent of the operator's
+	// attributeConvention toggle, so legacy dashboards and semconv
+	// dashboards both keep their expected label sets.
+	// request_bytes_total{target_host, method, path, user_agent} (legacy)
+	legacyAttributes := httpconv.RequestAttributes(request, targetHost, httpconv.ConventionLegacy)
+	legacyOptions := metric.WithAttributes(legacyAttributes...)
 
 	meter := otel.Meter(constants.SERVICE_TELEMETRY_KEY)
 	intrument, err := meter.Int64Counter(constants.METRIC_REQUEST_BYTES_TOTAL)
 	if err == nil {
-		options := metric.WithAttributes(metricAttributes...)
-		intrument.Add(ctx, request.ContentLength, options)
+		intrument.Add(ctx, request.ContentLength, legacyOptions)
 	}
+
+	// http.server.request.body.size{...} (semconv)
+	semConvAttributes := httpconv.RequestAttributes(request, targetHost, httpconv.ConventionSemConv)
+	instruments.RequestBodySize.Add(ctx, request.ContentLength, metric.WithAttributes(semConvAttributes...))
 }
 
 // This will be called when there is an error in forwarding the request
-func handleError(response http.ResponseWriter, request *http.Request, response_err error) {
+func handleError(response http.ResponseWriter, request *http.Request, response_err error, attributeConvention string) {
 	// Record traces
 	ctx, span := otel.Tracer(constants.SERVICE_TELEMETRY_KEY).Start(request.Context(), "handleError")
 	defer span.End()
 
-	attributes := []attribute.KeyValue{
-		attribute.String("response.status_code", response.Header().Get(constants.HEADER_STATUS_CODE)),
+	// requests_total{target_host, method, path, user_agent, status_code}
+	status_code, err := strconv.ParseInt(response.Header().Get(constants.HEADER_STATUS_CODE), 10, 32)
+	if err != nil {
+		log.WithContext(ctx).Errorln("Failed to parse status code, returning status code 503")
+		status_code = http.StatusServiceUnavailable
+	}
+
+	attributes := httpconv.SpanRequestAttributes(request, request.URL.Host, attributeConvention)
+	attributes = append(attributes, httpconv.SpanStatusAttributes(int(status_code), attributeConvention)...)
+	attributes = append(attributes,
 		attribute.String("response.content_type", response.Header().Get(constants.HEADER_CONTENT_TYPE)),
 		attribute.String("response.content_encoding", response.Header().Get(constants.HEADER_CONTENT_ENCODING)),
 		attribute.String("response.request_id", response.Header().Get(constants.HEADER_REQUEST_ID)),
-		attribute.String("response.error.message", response_err.Error()),
-	}
+	)
+	attributes = append(attributes, httpconv.ErrorAttributes(response_err, attributeConvention)...)
 
 	span.SetAttributes(attributes...)
 	span.RecordError(response_err)
 	span.SetStatus(codes.Error, "failed to forward request")
 
 	// Log error
-	log.WithFields(log.Fields{
+	log.WithContext(ctx).WithFields(log.Fields{
 		"Request": request.URL.String(),
 	}).Errorln("Request failed", response_err)
 
-	// Record metrics
-	// requests_total{target_host, method, path, user_agent, status_code}
-	status_code, err := strconv.ParseInt(response.Header().Get(constants.HEADER_STATUS_CODE), 10, 32)
-	if err != nil {
-		log.Errorln("Failed to parse status code, returning status code 503")
-		status_code = http.StatusServiceUnavailable
-	}
-
-	metricAttributes := []attribute.KeyValue{
-		attribute.String("target_host", request.URL.Host),
-		attribute.String("method", request.Method),
-		attribute.String("path", request.URL.Path),
-		attribute.String("user_agent", request.Header.Get(constants.HEADER_USER_AGENT)),
-		attribute.Int("status_code", int(status_code)),
-	}
+	// Record metrics. requests_total is a legacy-named instrument, so its
+	// attributes always use the legacy convention regardless of the
+	// operator's attributeConvention toggle, which only governs trace
+	// attributes.
+	metricAttributes := httpconv.RequestAttributes(request, request.URL.Host, httpconv.ConventionLegacy)
+	metricAttributes = append(metricAttributes, httpconv.StatusAttributes(int(status_code), httpconv.ConventionLegacy)...)
 
 	requestCountMeter := otel.Meter(constants.SERVICE_TELEMETRY_KEY)
 	requestCountIntrument, err := requestCountMeter.Int64Counter(constants.METRIC_REQUESTS_TOTAL)
@@ -119,50 +152,57 @@ func handleError(response http.ResponseWriter, request *http.Request, response_e
 }
 
 // This will be called once we receive response from targetHost
-func modifyResponse(response *http.Response) (err error) {
+func modifyResponse(response *http.Response, attributeConvention string, instruments *httpconv.Instruments, capturedResponseHeaders []string, sensitiveHeaders []string, headerRedactionValue string) (err error) {
 	// Record traces
 	ctx, span := otel.Tracer(constants.SERVICE_TELEMETRY_KEY).Start(response.Request.Context(), "modifyResponse")
 	defer span.End()
 
 	traceAttributes := []attribute.KeyValue{
-		attribute.Int("response.status_code", response.StatusCode),
 		attribute.String("response.content_length", response.Header.Get(constants.HEADER_CONTENT_LENGTH)),
 		attribute.String("response.content_type", response.Header.Get(constants.HEADER_CONTENT_TYPE)),
 		attribute.String("response.content_encoding", response.Header.Get(constants.HEADER_CONTENT_ENCODING)),
 		attribute.String("response.request_id", response.Header.Get(constants.HEADER_REQUEST_ID)),
 	}
+	traceAttributes = append(traceAttributes, httpconv.SpanStatusAttributes(response.StatusCode, attributeConvention)...)
+	traceAttributes = append(traceAttributes, httpconv.CapturedHeaderAttributes(
+		response.Header, capturedResponseHeaders, sensitiveHeaders, headerRedactionValue, httpconv.DirectionResponse,
+	)...)
 
 	span.SetAttributes(traceAttributes...)
 
-	// Metric attributes
-	metricAttributes := []attribute.KeyValue{
-		attribute.String("target_host", response.Request.URL.Host),
-		attribute.String("method", response.Request.Method),
-		attribute.String("path", response.Request.URL.Path),
-		attribute.String("user_agent", response.Request.Header.Get(constants.HEADER_USER_AGENT)),
-		attribute.Int("status_code", response.StatusCode),
-	}
+	// Metric attributes. Legacy-named instruments always use the legacy
+	// convention and semconv-named instruments always use the semconv
+	// convention, independent of the operator's attributeConvention toggle,
+	// which only governs trace attributes.
+	legacyAttributes := httpconv.RequestAttributes(response.Request, response.Request.URL.Host, httpconv.ConventionLegacy)
+	legacyAttributes = append(legacyAttributes, httpconv.StatusAttributes(response.StatusCode, httpconv.ConventionLegacy)...)
+	legacyOptions := metric.WithAttributes(legacyAttributes...)
+
+	semConvAttributes := httpconv.RequestAttributes(response.Request, response.Request.URL.Host, httpconv.ConventionSemConv)
+	semConvAttributes = append(semConvAttributes, httpconv.StatusAttributes(response.StatusCode, httpconv.ConventionSemConv)...)
+	semConvOptions := metric.WithAttributes(semConvAttributes...)
 
 	// Record metrics
 	// requests_total{target_host, method, path, user_agent, status_code}
 	requestCountMeter := otel.Meter(constants.SERVICE_TELEMETRY_KEY)
 	requestCountIntrument, err := requestCountMeter.Int64Counter(constants.METRIC_REQUESTS_TOTAL)
 	if err == nil {
-		options := metric.WithAttributes(metricAttributes...)
-		requestCountIntrument.Add(ctx, 1, options)
+		requestCountIntrument.Add(ctx, 1, legacyOptions)
 	}
 
 	// Record metrics
-	// response_bytes_total{target_host, method, path, user_agent, status_code}
+	// response_bytes_total{target_host, method, path, user_agent, status_code} (legacy)
 	responseBytesMeter := otel.Meter(constants.SERVICE_TELEMETRY_KEY)
 	responseBytesIntrument, err := responseBytesMeter.Int64Counter(constants.METRIC_RESPONSE_BYTES_TOTAL)
 	if err == nil {
-		options := metric.WithAttributes(metricAttributes...)
-		responseBytesIntrument.Add(ctx, response.ContentLength, options)
+		responseBytesIntrument.Add(ctx, response.ContentLength, legacyOptions)
 	}
 
+	// http.server.response.body.size{...} (semconv)
+	instruments.ResponseBodySize.Add(ctx, response.ContentLength, semConvOptions)
+
 	// Log response
-	log.WithFields(log.Fields{
+	log.WithContext(ctx).WithFields(log.Fields{
 		"Request":       response.Request.URL.String(),
 		"StatusCode":    response.StatusCode,
 		"ContentLength": response.ContentLength,
@@ -194,17 +234,17 @@ func logResponse(ctx context.Context, response *http.Response) {
 
 	responseBody, err = encoderDecoder.Decode(encoding, response.Body)
 	if err != nil {
-		log.Errorln("Failed to decode response body", err)
+		log.WithContext(ctx).Errorln("Failed to decode response body", err)
 		return
 	}
 
-	log.WithFields(log.Fields{
+	log.WithContext(ctx).WithFields(log.Fields{
 		"Encoding": encoding,
 	}).Errorln("Error response body: ", string(responseBody[:]))
 
 	buffer, err = encoderDecoder.Encode(encoding, responseBody)
 	if err != nil {
-		log.Errorln("Failed to encode response body", err)
+		log.WithContext(ctx).Errorln("Failed to encode response body", err)
 		return
 	}
 
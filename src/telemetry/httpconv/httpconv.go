@@ -0,0 +1,299 @@
+// Package httpconv centralizes HTTP attribute extraction for traces and
+// metrics so the proxy's request, response, and error paths always emit
+// identical label sets.
+package httpconv
+
+import (
+	"fmt"
+	"net"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/metric"
+)
+
+// Attribute naming conventions supported by the proxy. Operators pick one via
+// utils.IConfiguration so existing dashboards keep working while new ones can
+// move to the OTel stable HTTP semantic conventions.
+const (
+	ConventionLegacy  = "legacy"
+	ConventionSemConv = "semconv"
+	ConventionBoth    = "both"
+)
+
+// ValidConvention reports whether convention is one of the recognized
+// attribute-naming conventions. Callers should validate
+// utils.IConfiguration's OtelAttributeConvention against this once at
+// construction time rather than letting an unrecognized value silently
+// decay to an empty attribute set at every call site.
+func ValidConvention(convention string) bool {
+	switch convention {
+	case ConventionLegacy, ConventionSemConv, ConventionBoth:
+		return true
+	default:
+		return false
+	}
+}
+
+// Instruments holds the standard OTel HTTP server instruments shared by the
+// handler (request duration), director (request body size), and
+// modifyResponse (response body size) paths.
+type Instruments struct {
+	RequestDuration  metric.Float64Histogram
+	RequestBodySize  metric.Int64Counter
+	ResponseBodySize metric.Int64Counter
+}
+
+// NewInstruments registers the http.server.request.duration histogram and the
+// http.server.request/response.body.size counters on the given meter.
+func NewInstruments(meter metric.Meter) (*Instruments, error) {
+	requestDuration, err := meter.Float64Histogram(
+		"http.server.request.duration",
+		metric.WithUnit("s"),
+		metric.WithDescription("Duration of HTTP server requests."),
+		metric.WithExplicitBucketBoundaries(0.005, 0.01, 0.025, 0.05, 0.1, 0.25, 0.5, 1, 2.5, 5, 10),
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	requestBodySize, err := meter.Int64Counter(
+		"http.server.request.body.size",
+		metric.WithUnit("By"),
+		metric.WithDescription("Size of HTTP server request bodies."),
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	responseBodySize, err := meter.Int64Counter(
+		"http.server.response.body.size",
+		metric.WithUnit("By"),
+		metric.WithDescription("Size of HTTP server response bodies."),
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	return &Instruments{
+		RequestDuration:  requestDuration,
+		RequestBodySize:  requestBodySize,
+		ResponseBodySize: responseBodySize,
+	}, nil
+}
+
+// RequestAttributes builds the metric label set describing an inbound or
+// forwarded request, honoring the configured naming convention. The legacy
+// branch reproduces the metric label names used before this series
+// (target_host, method, path, user_agent); for the matching span attribute
+// names use SpanRequestAttributes instead.
+func RequestAttributes(request *http.Request, targetHost, convention string) []attribute.KeyValue {
+	var attributes []attribute.KeyValue
+
+	if convention == ConventionLegacy || convention == ConventionBoth {
+		attributes = append(attributes,
+			attribute.String("target_host", targetHost),
+			attribute.String("method", request.Method),
+			attribute.String("path", request.URL.Path),
+			attribute.String("user_agent", request.Header.Get("User-Agent")),
+		)
+	}
+
+	if convention == ConventionSemConv || convention == ConventionBoth {
+		attributes = append(attributes, semConvRequestAttributes(request, targetHost)...)
+	}
+
+	return attributes
+}
+
+// SpanRequestAttributes builds the span attribute set describing an inbound
+// or forwarded request, honoring the configured naming convention. The
+// legacy branch reproduces the per-span attribute names used before this
+// series (request.method, request.path, request.user_agent); for the
+// matching metric label names use RequestAttributes instead.
+func SpanRequestAttributes(request *http.Request, targetHost, convention string) []attribute.KeyValue {
+	var attributes []attribute.KeyValue
+
+	if convention == ConventionLegacy || convention == ConventionBoth {
+		attributes = append(attributes,
+			attribute.String("request.method", request.Method),
+			attribute.String("request.path", request.URL.Path),
+			attribute.String("request.user_agent", request.Header.Get("User-Agent")),
+		)
+	}
+
+	if convention == ConventionSemConv || convention == ConventionBoth {
+		attributes = append(attributes, semConvRequestAttributes(request, targetHost)...)
+	}
+
+	return attributes
+}
+
+// semConvRequestAttributes builds the OTel stable HTTP semantic-convention
+// request attributes shared by RequestAttributes and SpanRequestAttributes;
+// the stable conventions use identical names on spans and metrics, so only
+// the legacy branch differs between the two.
+func semConvRequestAttributes(request *http.Request, targetHost string) []attribute.KeyValue {
+	attributes := []attribute.KeyValue{
+		attribute.String("http.request.method", request.Method),
+		attribute.String("url.path", request.URL.Path),
+		attribute.String("url.scheme", scheme(request)),
+		attribute.String("user_agent.original", request.Header.Get("User-Agent")),
+		attribute.String("network.protocol.version", protocolVersion(request)),
+	}
+
+	if address, port, ok := splitHostPort(targetHost); ok {
+		attributes = append(attributes,
+			attribute.String("server.address", address),
+			attribute.Int("server.port", port),
+		)
+	} else if targetHost != "" {
+		attributes = append(attributes, attribute.String("server.address", targetHost))
+	}
+
+	return attributes
+}
+
+// StatusAttributes builds the metric label set describing a response status
+// code, honoring the configured naming convention. The legacy branch
+// reproduces the metric label name used before this series (status_code);
+// for the matching span attribute name use SpanStatusAttributes instead.
+func StatusAttributes(statusCode int, convention string) []attribute.KeyValue {
+	var attributes []attribute.KeyValue
+
+	if convention == ConventionLegacy || convention == ConventionBoth {
+		attributes = append(attributes, attribute.Int("status_code", statusCode))
+	}
+
+	if convention == ConventionSemConv || convention == ConventionBoth {
+		attributes = append(attributes, attribute.Int("http.response.status_code", statusCode))
+	}
+
+	return attributes
+}
+
+// SpanStatusAttributes builds the span attribute set describing a response
+// status code, honoring the configured naming convention. The legacy branch
+// reproduces the per-span attribute name used before this series
+// (response.status_code); for the matching metric label name use
+// StatusAttributes instead.
+func SpanStatusAttributes(statusCode int, convention string) []attribute.KeyValue {
+	var attributes []attribute.KeyValue
+
+	if convention == ConventionLegacy || convention == ConventionBoth {
+		attributes = append(attributes, attribute.Int("response.status_code", statusCode))
+	}
+
+	if convention == ConventionSemConv || convention == ConventionBoth {
+		attributes = append(attributes, attribute.Int("http.response.status_code", statusCode))
+	}
+
+	return attributes
+}
+
+// ErrorAttributes builds the attribute set describing a failed request,
+// honoring the configured naming convention.
+func ErrorAttributes(err error, convention string) []attribute.KeyValue {
+	if err == nil {
+		return nil
+	}
+
+	var attributes []attribute.KeyValue
+
+	if convention == ConventionLegacy || convention == ConventionBoth {
+		attributes = append(attributes, attribute.String("response.error.message", err.Error()))
+	}
+
+	if convention == ConventionSemConv || convention == ConventionBoth {
+		attributes = append(attributes, attribute.String("error.type", errorType(err)))
+	}
+
+	return attributes
+}
+
+// errorType returns the Go type name of err, which is what the semconv
+// error.type attribute expects when no well-known error code applies.
+func errorType(err error) string {
+	return fmt.Sprintf("%T", err)
+}
+
+// Header directions supported by CapturedHeaderAttributes, matching the
+// http.request.header.<key> / http.response.header.<key> semconv arrays.
+const (
+	DirectionRequest  = "request"
+	DirectionResponse = "response"
+)
+
+// CapturedHeaderAttributes attaches one attribute per name in headerNames
+// that is present on header, following the OTel convention of naming them
+// http.<direction>.header.<lowercased-name> with a string-array value. Any
+// header whose lowercased name appears in sensitiveNames has its values
+// replaced with redactionValue instead of being dropped, so operators can see
+// that the header was sent without leaking its contents.
+func CapturedHeaderAttributes(header http.Header, headerNames []string, sensitiveNames []string, redactionValue string, direction string) []attribute.KeyValue {
+	if len(headerNames) == 0 {
+		return nil
+	}
+
+	sensitive := make(map[string]struct{}, len(sensitiveNames))
+	for _, name := range sensitiveNames {
+		sensitive[strings.ToLower(name)] = struct{}{}
+	}
+
+	var attributes []attribute.KeyValue
+	for _, name := range headerNames {
+		values := header.Values(name)
+		if len(values) == 0 {
+			continue
+		}
+
+		lowerName := strings.ToLower(name)
+		if _, redact := sensitive[lowerName]; redact {
+			values = []string{redactionValue}
+		}
+
+		key := fmt.Sprintf("http.%s.header.%s", direction, lowerName)
+		attributes = append(attributes, attribute.StringSlice(key, values))
+	}
+
+	return attributes
+}
+
+func scheme(request *http.Request) string {
+	if request.URL != nil && request.URL.Scheme != "" {
+		return request.URL.Scheme
+	}
+	if request.TLS != nil {
+		return "https"
+	}
+	return "http"
+}
+
+func protocolVersion(request *http.Request) string {
+	switch request.Proto {
+	case "HTTP/1.0":
+		return "1.0"
+	case "HTTP/1.1":
+		return "1.1"
+	case "HTTP/2.0":
+		return "2"
+	case "HTTP/3.0":
+		return "3"
+	default:
+		return strings.TrimPrefix(request.Proto, "HTTP/")
+	}
+}
+
+func splitHostPort(hostport string) (host string, port int, ok bool) {
+	h, p, err := net.SplitHostPort(hostport)
+	if err != nil {
+		return "", 0, false
+	}
+	portNum, err := strconv.Atoi(p)
+	if err != nil {
+		return "", 0, false
+	}
+	return h, portNum, true
+}
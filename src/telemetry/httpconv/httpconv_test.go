@@ -0,0 +1,271 @@
+package httpconv
+
+import (
+	"errors"
+	"net/http"
+	"net/url"
+	"testing"
+
+	"go.opentelemetry.io/otel/attribute"
+)
+
+func attrMap(attributes []attribute.KeyValue) map[string]attribute.Value {
+	byKey := make(map[string]attribute.Value, len(attributes))
+	for _, kv := range attributes {
+		byKey[string(kv.Key)] = kv.Value
+	}
+	return byKey
+}
+
+func newTestRequest(t *testing.T) *http.Request {
+	t.Helper()
+
+	parsed, err := url.Parse("https://example.com/widgets?id=42")
+	if err != nil {
+		t.Fatalf("failed to parse test URL: %v", err)
+	}
+
+	request := &http.Request{
+		Method: http.MethodGet,
+		URL:    parsed,
+		Proto:  "HTTP/1.1",
+		Header: http.Header{},
+	}
+	request.Header.Set("User-Agent", "test-agent")
+
+	return request
+}
+
+func TestValidConvention(t *testing.T) {
+	tests := []struct {
+		name       string
+		convention string
+		want       bool
+	}{
+		{name: "legacy", convention: ConventionLegacy, want: true},
+		{name: "semconv", convention: ConventionSemConv, want: true},
+		{name: "both", convention: ConventionBoth, want: true},
+		{name: "empty", convention: "", want: false},
+		{name: "unknown", convention: "banana", want: false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := ValidConvention(tt.convention); got != tt.want {
+				t.Errorf("ValidConvention(%q) = %v, want %v", tt.convention, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestSplitHostPort(t *testing.T) {
+	tests := []struct {
+		name     string
+		hostport string
+		wantHost string
+		wantPort int
+		wantOk   bool
+	}{
+		{name: "bare host", hostport: "example.com", wantOk: false},
+		{name: "host and port", hostport: "example.com:8443", wantHost: "example.com", wantPort: 8443, wantOk: true},
+		{name: "empty string", hostport: "", wantOk: false},
+		{name: "non-numeric port", hostport: "example.com:https", wantOk: false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			host, port, ok := splitHostPort(tt.hostport)
+			if ok != tt.wantOk {
+				t.Fatalf("splitHostPort(%q) ok = %v, want %v", tt.hostport, ok, tt.wantOk)
+			}
+			if !tt.wantOk {
+				return
+			}
+			if host != tt.wantHost || port != tt.wantPort {
+				t.Errorf("splitHostPort(%q) = (%q, %d), want (%q, %d)", tt.hostport, host, port, tt.wantHost, tt.wantPort)
+			}
+		})
+	}
+}
+
+func TestRequestAttributes(t *testing.T) {
+	tests := []struct {
+		name        string
+		convention  string
+		wantLegacy  bool
+		wantSemConv bool
+	}{
+		{name: "legacy", convention: ConventionLegacy, wantLegacy: true, wantSemConv: false},
+		{name: "semconv", convention: ConventionSemConv, wantLegacy: false, wantSemConv: true},
+		{name: "both", convention: ConventionBoth, wantLegacy: true, wantSemConv: true},
+		{name: "unknown convention emits nothing", convention: "banana", wantLegacy: false, wantSemConv: false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			byKey := attrMap(RequestAttributes(newTestRequest(t), "example.com:8443", tt.convention))
+
+			if _, ok := byKey["target_host"]; ok != tt.wantLegacy {
+				t.Errorf("target_host present = %v, want %v", ok, tt.wantLegacy)
+			}
+			if _, ok := byKey["method"]; ok != tt.wantLegacy {
+				t.Errorf("method present = %v, want %v", ok, tt.wantLegacy)
+			}
+			if _, ok := byKey["http.request.method"]; ok != tt.wantSemConv {
+				t.Errorf("http.request.method present = %v, want %v", ok, tt.wantSemConv)
+			}
+			if _, ok := byKey["server.port"]; ok != tt.wantSemConv {
+				t.Errorf("server.port present = %v, want %v", ok, tt.wantSemConv)
+			}
+		})
+	}
+}
+
+func TestSpanRequestAttributesUsesOriginalSpanKeys(t *testing.T) {
+	byKey := attrMap(SpanRequestAttributes(newTestRequest(t), "example.com:8443", ConventionLegacy))
+
+	if _, ok := byKey["target_host"]; ok {
+		t.Errorf("expected no target_host span attribute (that is a metric-only label), got one")
+	}
+
+	if got, ok := byKey["request.method"]; !ok || got.AsString() != http.MethodGet {
+		t.Errorf("request.method = %v, ok %v, want %q", got, ok, http.MethodGet)
+	}
+
+	if got, ok := byKey["request.path"]; !ok || got.AsString() != "/widgets" {
+		t.Errorf("request.path = %v, ok %v, want %q", got, ok, "/widgets")
+	}
+
+	if got, ok := byKey["request.user_agent"]; !ok || got.AsString() != "test-agent" {
+		t.Errorf("request.user_agent = %v, ok %v, want %q", got, ok, "test-agent")
+	}
+}
+
+func TestStatusAttributes(t *testing.T) {
+	tests := []struct {
+		name        string
+		convention  string
+		wantLegacy  bool
+		wantSemConv bool
+	}{
+		{name: "legacy", convention: ConventionLegacy, wantLegacy: true, wantSemConv: false},
+		{name: "semconv", convention: ConventionSemConv, wantLegacy: false, wantSemConv: true},
+		{name: "both", convention: ConventionBoth, wantLegacy: true, wantSemConv: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			byKey := attrMap(StatusAttributes(http.StatusOK, tt.convention))
+
+			if got, ok := byKey["status_code"]; ok != tt.wantLegacy {
+				t.Errorf("status_code present = %v, want %v", ok, tt.wantLegacy)
+			} else if ok && got.AsInt64() != http.StatusOK {
+				t.Errorf("status_code = %d, want %d", got.AsInt64(), http.StatusOK)
+			}
+
+			if _, ok := byKey["http.response.status_code"]; ok != tt.wantSemConv {
+				t.Errorf("http.response.status_code present = %v, want %v", ok, tt.wantSemConv)
+			}
+		})
+	}
+}
+
+func TestSpanStatusAttributesUsesOriginalSpanKey(t *testing.T) {
+	byKey := attrMap(SpanStatusAttributes(http.StatusOK, ConventionLegacy))
+
+	if _, ok := byKey["status_code"]; ok {
+		t.Errorf("expected no status_code span attribute (that is a metric-only label), got one")
+	}
+
+	if got, ok := byKey["response.status_code"]; !ok || got.AsInt64() != http.StatusOK {
+		t.Errorf("response.status_code = %v, ok %v, want %d", got, ok, http.StatusOK)
+	}
+}
+
+func TestErrorAttributes(t *testing.T) {
+	if got := ErrorAttributes(nil, ConventionBoth); got != nil {
+		t.Errorf("expected no attributes for a nil error, got %v", got)
+	}
+
+	byKey := attrMap(ErrorAttributes(errors.New("boom"), ConventionBoth))
+
+	if got, ok := byKey["response.error.message"]; !ok || got.AsString() != "boom" {
+		t.Errorf("response.error.message = %v, ok %v, want %q", got, ok, "boom")
+	}
+
+	if _, ok := byKey["error.type"]; !ok {
+		t.Errorf("expected error.type attribute under semconv/both conventions")
+	}
+}
+
+func TestCapturedHeaderAttributes(t *testing.T) {
+	header := http.Header{}
+	header.Set("Authorization", "Bearer secret-token")
+	header.Set("X-Ms-Request-Id", "request-123")
+	header.Add("X-Forwarded-For", "10.0.0.1")
+	header.Add("X-Forwarded-For", "10.0.0.2")
+	header.Set("X-Not-Captured", "should-never-appear")
+
+	captured := []string{"Authorization", "X-Ms-Request-Id", "X-Forwarded-For"}
+	sensitive := []string{"authorization"}
+
+	attributes := CapturedHeaderAttributes(header, captured, sensitive, "REDACTED", DirectionRequest)
+	byKey := make(map[string]attribute.Value, len(attributes))
+	for _, kv := range attributes {
+		byKey[string(kv.Key)] = kv.Value
+	}
+
+	t.Run("sensitive header is redacted", func(t *testing.T) {
+		value, ok := byKey["http.request.header.authorization"]
+		if !ok {
+			t.Fatalf("expected http.request.header.authorization attribute to be present")
+		}
+
+		got := value.AsStringSlice()
+		want := []string{"REDACTED"}
+		if len(got) != len(want) || got[0] != want[0] {
+			t.Errorf("authorization header = %v, want %v (real value must never be emitted)", got, want)
+		}
+	})
+
+	t.Run("non-captured header is never emitted", func(t *testing.T) {
+		if _, ok := byKey["http.request.header.x-not-captured"]; ok {
+			t.Errorf("expected x-not-captured to be absent, got an attribute for it")
+		}
+	})
+
+	t.Run("multi-value header is preserved as a string slice", func(t *testing.T) {
+		value, ok := byKey["http.request.header.x-forwarded-for"]
+		if !ok {
+			t.Fatalf("expected http.request.header.x-forwarded-for attribute to be present")
+		}
+
+		got := value.AsStringSlice()
+		want := []string{"10.0.0.1", "10.0.0.2"}
+		if len(got) != len(want) || got[0] != want[0] || got[1] != want[1] {
+			t.Errorf("x-forwarded-for header = %v, want %v", got, want)
+		}
+	})
+
+	t.Run("non-sensitive header keeps its real value", func(t *testing.T) {
+		value, ok := byKey["http.request.header.x-ms-request-id"]
+		if !ok {
+			t.Fatalf("expected http.request.header.x-ms-request-id attribute to be present")
+		}
+
+		got := value.AsStringSlice()
+		want := []string{"request-123"}
+		if len(got) != len(want) || got[0] != want[0] {
+			t.Errorf("x-ms-request-id header = %v, want %v", got, want)
+		}
+	})
+}
+
+func TestCapturedHeaderAttributesNoNamesConfigured(t *testing.T) {
+	header := http.Header{}
+	header.Set("Authorization", "Bearer secret-token")
+
+	if attributes := CapturedHeaderAttributes(header, nil, nil, "REDACTED", DirectionRequest); attributes != nil {
+		t.Errorf("expected no attributes when no header names are configured, got %v", attributes)
+	}
+}
@@ -0,0 +1,162 @@
+package telemetry
+
+import (
+	"aad-auth-proxy/constants"
+	"aad-auth-proxy/contracts"
+	"aad-auth-proxy/utils"
+	"context"
+	"fmt"
+
+	log "github.com/sirupsen/logrus"
+	"go.opentelemetry.io/otel/exporters/otlp/otlplog/otlploggrpc"
+	"go.opentelemetry.io/otel/exporters/otlp/otlplog/otlploghttp"
+	apilog "go.opentelemetry.io/otel/log"
+	"go.opentelemetry.io/otel/log/global"
+	sdklog "go.opentelemetry.io/otel/sdk/log"
+	"google.golang.org/grpc/credentials"
+)
+
+// InitializeLogger wires request logs into the same OTLP pipeline as traces
+// and metrics: it builds an OTel LoggerProvider backed by the configured
+// exporter and installs a logrus hook that forwards every entry to it,
+// correlated with the active span.
+func InitializeLogger(logger contracts.ILogger, configuration utils.IConfiguration) (func(context.Context) error, error) {
+	var providerOptions []sdklog.LoggerProviderOption
+	providerOptions = append(providerOptions, sdklog.WithResource(NewResource(configuration.GetOtelServiceName())))
+
+	// Add exporter only if endpoint is set
+	if configuration.GetOtelEndpoint() != "" {
+		exporter, err := newLogExporter(configuration)
+		if err != nil {
+			logger.Fatal(err)
+		}
+		providerOptions = append(providerOptions, sdklog.WithProcessor(sdklog.NewBatchProcessor(exporter)))
+	}
+
+	loggerProvider := sdklog.NewLoggerProvider(providerOptions...)
+
+	global.SetLoggerProvider(loggerProvider)
+	log.AddHook(newOtelHook(loggerProvider))
+
+	return loggerProvider.Shutdown, nil
+}
+
+// newLogExporter builds the otlploggrpc/otlploghttp exporter for the
+// protocol selected by configuration, sharing its TLS/insecure/compression
+// decision with newTraceExporter and newMetricExporter via
+// resolveTransportOptions.
+func newLogExporter(configuration utils.IConfiguration) (sdklog.Exporter, error) {
+	transport, err := resolveTransportOptions(configuration)
+	if err != nil {
+		return nil, err
+	}
+
+	switch otelProtocol(configuration) {
+	case OtelProtocolHTTPProtobuf:
+		options := []otlploghttp.Option{
+			otlploghttp.WithEndpoint(configuration.GetOtelEndpoint()),
+			otlploghttp.WithHeaders(configuration.GetOtelHeaders()),
+			otlploghttp.WithRetry(otlploghttp.RetryConfig{
+				Enabled:         true,
+				InitialInterval: retryInitialInterval,
+				MaxInterval:     retryMaxInterval,
+				MaxElapsedTime:  configuration.GetOtelRetryMaxElapsedTime(),
+			}),
+		}
+
+		if transport.gzip {
+			options = append(options, otlploghttp.WithCompression(otlploghttp.GzipCompression))
+		}
+
+		switch {
+		case transport.insecure:
+			options = append(options, otlploghttp.WithInsecure())
+		case transport.tlsConfig != nil:
+			options = append(options, otlploghttp.WithTLSClientConfig(transport.tlsConfig))
+		}
+
+		return otlploghttp.New(context.Background(), options...)
+	default:
+		options := []otlploggrpc.Option{
+			otlploggrpc.WithEndpoint(configuration.GetOtelEndpoint()),
+			otlploggrpc.WithHeaders(configuration.GetOtelHeaders()),
+			otlploggrpc.WithRetry(otlploggrpc.RetryConfig{
+				Enabled:         true,
+				InitialInterval: retryInitialInterval,
+				MaxInterval:     retryMaxInterval,
+				MaxElapsedTime:  configuration.GetOtelRetryMaxElapsedTime(),
+			}),
+		}
+
+		if transport.gzip {
+			options = append(options, otlploggrpc.WithCompressor(compressionGzip))
+		}
+
+		switch {
+		case transport.insecure:
+			options = append(options, otlploggrpc.WithInsecure())
+		case transport.tlsConfig != nil:
+			options = append(options, otlploggrpc.WithTLSCredentials(credentials.NewTLS(transport.tlsConfig)))
+		}
+
+		return otlploggrpc.New(context.Background(), options...)
+	}
+}
+
+// otelHook bridges logrus entries into the OTel log pipeline, correlating
+// each record with the active span via trace_id/span_id extracted from the
+// entry's context.
+type otelHook struct {
+	otelLogger apilog.Logger
+}
+
+func newOtelHook(provider *sdklog.LoggerProvider) *otelHook {
+	return &otelHook{otelLogger: provider.Logger(constants.SERVICE_TELEMETRY_KEY)}
+}
+
+func (hook *otelHook) Levels() []log.Level {
+	return log.AllLevels
+}
+
+func (hook *otelHook) Fire(entry *log.Entry) error {
+	var record apilog.Record
+	record.SetTimestamp(entry.Time)
+	record.SetBody(apilog.StringValue(entry.Message))
+	record.SetSeverity(severityFromLevel(entry.Level))
+	record.SetSeverityText(entry.Level.String())
+
+	for key, value := range entry.Data {
+		record.AddAttributes(apilog.String(key, fmt.Sprint(value)))
+	}
+
+	ctx := entry.Context
+	if ctx == nil {
+		ctx = context.Background()
+	}
+
+	// Emit with ctx rather than context.Background(): the SDK reads the
+	// span context off ctx and sets the record's TraceID/SpanID fields
+	// itself, so correlation doesn't need to be duplicated as attributes.
+	hook.otelLogger.Emit(ctx, record)
+	return nil
+}
+
+// severityFromLevel maps logrus levels to the OTel SeverityNumber range.
+func severityFromLevel(level log.Level) apilog.Severity {
+	switch level {
+	case log.PanicLevel, log.FatalLevel:
+		return apilog.SeverityFatal
+	case log.ErrorLevel:
+		return apilog.SeverityError
+	case log.WarnLevel:
+		return apilog.SeverityWarn
+	case log.InfoLevel:
+		return apilog.SeverityInfo
+	case log.DebugLevel:
+		return apilog.SeverityDebug
+	case log.TraceLevel:
+		return apilog.SeverityTrace
+	default:
+		return apilog.SeverityInfo
+	}
+}
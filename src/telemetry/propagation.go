@@ -0,0 +1,53 @@
+package telemetry
+
+import (
+	"context"
+	"net/http"
+	"strings"
+
+	"go.opentelemetry.io/otel/trace"
+)
+
+// ExtractLegacyTraceContext parses a legacy "traceID:spanID" hex-encoded trace
+// header (e.g. cf-trace-id, x-ms-client-request-id) and, if valid, returns a
+// context carrying it as a remote parent span. It is a fallback for requests
+// arriving from CDN/edge layers that don't speak W3C tracecontext; ctx is
+// returned unchanged if headerName is unset or the header is missing or
+// malformed.
+func ExtractLegacyTraceContext(ctx context.Context, header http.Header, headerName string) context.Context {
+	if headerName == "" {
+		return ctx
+	}
+
+	value := header.Get(headerName)
+	if value == "" {
+		return ctx
+	}
+
+	parts := strings.SplitN(value, ":", 2)
+	if len(parts) != 2 {
+		return ctx
+	}
+
+	traceID, err := trace.TraceIDFromHex(parts[0])
+	if err != nil {
+		return ctx
+	}
+
+	spanID, err := trace.SpanIDFromHex(parts[1])
+	if err != nil {
+		return ctx
+	}
+
+	spanContext := trace.NewSpanContext(trace.SpanContextConfig{
+		TraceID:    traceID,
+		SpanID:     spanID,
+		TraceFlags: trace.FlagsSampled,
+		Remote:     true,
+	})
+	if !spanContext.IsValid() {
+		return ctx
+	}
+
+	return trace.ContextWithSpanContext(ctx, spanContext)
+}
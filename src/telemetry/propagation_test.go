@@ -0,0 +1,93 @@
+package telemetry
+
+import (
+	"context"
+	"net/http"
+	"testing"
+
+	"go.opentelemetry.io/otel/trace"
+)
+
+func TestExtractLegacyTraceContext(t *testing.T) {
+	const headerName = "cf-trace-id"
+	const validTraceID = "4bf92f3577b34da6a3ce929d0e0e4736"
+	const validSpanID = "00f067aa0ba902b7"
+
+	tests := []struct {
+		name        string
+		headerName  string
+		headerValue string
+		wantValid   bool
+	}{
+		{
+			name:        "missing header",
+			headerName:  headerName,
+			headerValue: "",
+			wantValid:   false,
+		},
+		{
+			name:        "header name not configured",
+			headerName:  "",
+			headerValue: validTraceID + ":" + validSpanID,
+			wantValid:   false,
+		},
+		{
+			name:        "malformed, no separator",
+			headerName:  headerName,
+			headerValue: validTraceID + validSpanID,
+			wantValid:   false,
+		},
+		{
+			name:        "invalid trace ID hex",
+			headerName:  headerName,
+			headerValue: "not-hex:" + validSpanID,
+			wantValid:   false,
+		},
+		{
+			name:        "invalid span ID hex",
+			headerName:  headerName,
+			headerValue: validTraceID + ":not-hex",
+			wantValid:   false,
+		},
+		{
+			name:        "all-zero trace ID is invalid",
+			headerName:  headerName,
+			headerValue: "00000000000000000000000000000000:" + validSpanID,
+			wantValid:   false,
+		},
+		{
+			name:        "valid header",
+			headerName:  headerName,
+			headerValue: validTraceID + ":" + validSpanID,
+			wantValid:   true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			header := http.Header{}
+			if tt.headerValue != "" {
+				header.Set(headerName, tt.headerValue)
+			}
+
+			ctx := ExtractLegacyTraceContext(context.Background(), header, tt.headerName)
+			spanContext := trace.SpanContextFromContext(ctx)
+
+			if spanContext.IsValid() != tt.wantValid {
+				t.Fatalf("IsValid() = %v, want %v", spanContext.IsValid(), tt.wantValid)
+			}
+
+			if tt.wantValid {
+				if got := spanContext.TraceID().String(); got != validTraceID {
+					t.Errorf("TraceID = %s, want %s", got, validTraceID)
+				}
+				if got := spanContext.SpanID().String(); got != validSpanID {
+					t.Errorf("SpanID = %s, want %s", got, validSpanID)
+				}
+				if !spanContext.IsRemote() {
+					t.Errorf("expected span context to be marked remote")
+				}
+			}
+		})
+	}
+}
@@ -4,14 +4,29 @@ import (
 	"aad-auth-proxy/contracts"
 	"aad-auth-proxy/utils"
 	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"fmt"
+	"os"
+	"time"
 
 	"go.opentelemetry.io/otel"
 	"go.opentelemetry.io/otel/exporters/otlp/otlpmetric/otlpmetricgrpc"
+	"go.opentelemetry.io/otel/exporters/otlp/otlpmetric/otlpmetrichttp"
 	"go.opentelemetry.io/otel/exporters/otlp/otlptrace"
 	"go.opentelemetry.io/otel/exporters/otlp/otlptrace/otlptracegrpc"
+	"go.opentelemetry.io/otel/exporters/otlp/otlptrace/otlptracehttp"
 	"go.opentelemetry.io/otel/propagation"
 	"go.opentelemetry.io/otel/sdk/metric"
 	"go.opentelemetry.io/otel/sdk/trace"
+	"google.golang.org/grpc/credentials"
+)
+
+// OtelProtocol values selecting the OTLP transport, mirroring the standard
+// OTEL_EXPORTER_OTLP_PROTOCOL values.
+const (
+	OtelProtocolGRPC         = "grpc"
+	OtelProtocolHTTPProtobuf = "http/protobuf"
 )
 
 func InitializeTracer(logger contracts.ILogger, configuration utils.IConfiguration) (func(context.Context) error, error) {
@@ -20,13 +35,7 @@ func InitializeTracer(logger contracts.ILogger, configuration utils.IConfigurati
 
 	// Add exporter only if endpoint is set
 	if configuration.GetOtelEndpoint() != "" {
-		// Create a new otlptrace exporter
-		exporter, err := otlptrace.New(context.Background(),
-			otlptracegrpc.NewClient(
-				otlptracegrpc.WithInsecure(),
-				otlptracegrpc.WithEndpoint(configuration.GetOtelEndpoint()),
-			),
-		)
+		exporter, err := newTraceExporter(configuration)
 		if err != nil {
 			logger.Fatal(err)
 		}
@@ -37,7 +46,10 @@ func InitializeTracer(logger contracts.ILogger, configuration utils.IConfigurati
 	traceProvider := trace.NewTracerProvider(traceOptions...)
 
 	otel.SetTracerProvider(traceProvider)
-	otel.SetTextMapPropagator(propagation.TraceContext{})
+	otel.SetTextMapPropagator(propagation.NewCompositeTextMapPropagator(
+		propagation.TraceContext{},
+		propagation.Baggage{},
+	))
 
 	return traceProvider.Shutdown, nil
 }
@@ -48,12 +60,7 @@ func InitializeMetric(logger contracts.ILogger, configuration utils.IConfigurati
 
 	// Add exporter only if endpoint is set
 	if configuration.GetOtelEndpoint() != "" {
-		// Create a new otlpmetric exporter
-		exporter, err := otlpmetricgrpc.New(context.Background(),
-			otlpmetricgrpc.WithInsecure(),
-			otlpmetricgrpc.WithEndpoint(configuration.GetOtelEndpoint()),
-		)
-
+		exporter, err := newMetricExporter(configuration)
 		if err != nil {
 			logger.Fatal(err)
 		}
@@ -67,3 +74,219 @@ func InitializeMetric(logger contracts.ILogger, configuration utils.IConfigurati
 
 	return metricProvider.Shutdown, nil
 }
+
+// newTraceExporter builds the otlptrace exporter for the protocol selected by
+// configuration, wiring in compression, TLS, custom headers, and a bounded
+// retry policy so the proxy can talk to managed OTLP endpoints that mandate
+// HTTPS and auth headers.
+func newTraceExporter(configuration utils.IConfiguration) (*otlptrace.Exporter, error) {
+	transport, err := resolveTransportOptions(configuration)
+	if err != nil {
+		return nil, err
+	}
+
+	switch otelProtocol(configuration) {
+	case OtelProtocolHTTPProtobuf:
+		options := []otlptracehttp.Option{
+			otlptracehttp.WithEndpoint(configuration.GetOtelEndpoint()),
+			otlptracehttp.WithHeaders(configuration.GetOtelHeaders()),
+			otlptracehttp.WithRetry(otlptracehttp.RetryConfig{
+				Enabled:         true,
+				InitialInterval: retryInitialInterval,
+				MaxInterval:     retryMaxInterval,
+				MaxElapsedTime:  configuration.GetOtelRetryMaxElapsedTime(),
+			}),
+		}
+
+		if transport.gzip {
+			options = append(options, otlptracehttp.WithCompression(otlptracehttp.GzipCompression))
+		}
+
+		switch {
+		case transport.insecure:
+			options = append(options, otlptracehttp.WithInsecure())
+		case transport.tlsConfig != nil:
+			options = append(options, otlptracehttp.WithTLSClientConfig(transport.tlsConfig))
+		}
+
+		return otlptrace.New(context.Background(), otlptracehttp.NewClient(options...))
+	default:
+		options := []otlptracegrpc.Option{
+			otlptracegrpc.WithEndpoint(configuration.GetOtelEndpoint()),
+			otlptracegrpc.WithHeaders(configuration.GetOtelHeaders()),
+			otlptracegrpc.WithRetry(otlptracegrpc.RetryConfig{
+				Enabled:         true,
+				InitialInterval: retryInitialInterval,
+				MaxInterval:     retryMaxInterval,
+				MaxElapsedTime:  configuration.GetOtelRetryMaxElapsedTime(),
+			}),
+		}
+
+		if transport.gzip {
+			options = append(options, otlptracegrpc.WithCompressor(compressionGzip))
+		}
+
+		switch {
+		case transport.insecure:
+			options = append(options, otlptracegrpc.WithInsecure())
+		case transport.tlsConfig != nil:
+			options = append(options, otlptracegrpc.WithTLSCredentials(credentials.NewTLS(transport.tlsConfig)))
+		}
+
+		return otlptrace.New(context.Background(), otlptracegrpc.NewClient(options...))
+	}
+}
+
+// newMetricExporter builds the otlpmetric exporter for the protocol selected
+// by configuration, mirroring newTraceExporter.
+func newMetricExporter(configuration utils.IConfiguration) (metric.Exporter, error) {
+	transport, err := resolveTransportOptions(configuration)
+	if err != nil {
+		return nil, err
+	}
+
+	switch otelProtocol(configuration) {
+	case OtelProtocolHTTPProtobuf:
+		options := []otlpmetrichttp.Option{
+			otlpmetrichttp.WithEndpoint(configuration.GetOtelEndpoint()),
+			otlpmetrichttp.WithHeaders(configuration.GetOtelHeaders()),
+			otlpmetrichttp.WithRetry(otlpmetrichttp.RetryConfig{
+				Enabled:         true,
+				InitialInterval: retryInitialInterval,
+				MaxInterval:     retryMaxInterval,
+				MaxElapsedTime:  configuration.GetOtelRetryMaxElapsedTime(),
+			}),
+		}
+
+		if transport.gzip {
+			options = append(options, otlpmetrichttp.WithCompression(otlpmetrichttp.GzipCompression))
+		}
+
+		switch {
+		case transport.insecure:
+			options = append(options, otlpmetrichttp.WithInsecure())
+		case transport.tlsConfig != nil:
+			options = append(options, otlpmetrichttp.WithTLSClientConfig(transport.tlsConfig))
+		}
+
+		return otlpmetrichttp.New(context.Background(), options...)
+	default:
+		options := []otlpmetricgrpc.Option{
+			otlpmetricgrpc.WithEndpoint(configuration.GetOtelEndpoint()),
+			otlpmetricgrpc.WithHeaders(configuration.GetOtelHeaders()),
+			otlpmetricgrpc.WithRetry(otlpmetricgrpc.RetryConfig{
+				Enabled:         true,
+				InitialInterval: retryInitialInterval,
+				MaxInterval:     retryMaxInterval,
+				MaxElapsedTime:  configuration.GetOtelRetryMaxElapsedTime(),
+			}),
+		}
+
+		if transport.gzip {
+			options = append(options, otlpmetricgrpc.WithCompressor(compressionGzip))
+		}
+
+		switch {
+		case transport.insecure:
+			options = append(options, otlpmetricgrpc.WithInsecure())
+		case transport.tlsConfig != nil:
+			options = append(options, otlpmetricgrpc.WithTLSCredentials(credentials.NewTLS(transport.tlsConfig)))
+		}
+
+		return otlpmetricgrpc.New(context.Background(), options...)
+	}
+}
+
+// otelProtocol resolves the configured OTLP transport, falling back to the
+// standard OTEL_EXPORTER_OTLP_PROTOCOL env var and then to gRPC so existing
+// deployments keep their current behavior.
+func otelProtocol(configuration utils.IConfiguration) string {
+	if protocol := configuration.GetOtelProtocol(); protocol != "" {
+		return protocol
+	}
+
+	if protocol := os.Getenv("OTEL_EXPORTER_OTLP_PROTOCOL"); protocol != "" {
+		return protocol
+	}
+
+	return OtelProtocolGRPC
+}
+
+// transportOptions is the protocol-agnostic TLS/insecure/compression
+// decision shared by every OTLP exporter (trace, metric, log) across both
+// the gRPC and HTTP transports. Each exporter builder maps these fields onto
+// its own otlp*.Option type, since the six exporter packages don't share an
+// Option interface, but the decision itself is resolved here once so the
+// six builders can't drift from one another.
+//
+// When insecure is false and tlsConfig is nil, callers should leave TLS
+// unconfigured so the exporter falls back to its default of trusting the
+// host's root CA set, which is exactly what managed endpoints (Azure
+// Monitor, Honeycomb) with publicly-trusted certs need.
+type transportOptions struct {
+	tlsConfig *tls.Config
+	insecure  bool
+	gzip      bool
+}
+
+// resolveTransportOptions resolves the transportOptions shared by every
+// OTLP exporter builder from configuration.
+func resolveTransportOptions(configuration utils.IConfiguration) (transportOptions, error) {
+	tlsConfig, err := buildTLSConfig(configuration)
+	if err != nil {
+		return transportOptions{}, err
+	}
+
+	return transportOptions{
+		tlsConfig: tlsConfig,
+		insecure:  configuration.GetOtelInsecure(),
+		gzip:      configuration.GetOtelCompression() == compressionGzip,
+	}, nil
+}
+
+// buildTLSConfig assembles a tls.Config layering a custom CA bundle and/or
+// mTLS client certificate on top of the exporter's default TLS transport. It
+// returns nil when neither is set, in which case callers should leave TLS
+// unconfigured so the exporter falls back to its default of trusting the
+// host's root CA set.
+func buildTLSConfig(configuration utils.IConfiguration) (*tls.Config, error) {
+	caFile := configuration.GetOtelTLSCAFile()
+	certFile := configuration.GetOtelTLSCertFile()
+	keyFile := configuration.GetOtelTLSKeyFile()
+
+	if caFile == "" && certFile == "" && keyFile == "" {
+		return nil, nil
+	}
+
+	tlsConfig := &tls.Config{}
+
+	if caFile != "" {
+		caCert, err := os.ReadFile(caFile)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read otel TLS CA file: %w", err)
+		}
+
+		caPool := x509.NewCertPool()
+		if !caPool.AppendCertsFromPEM(caCert) {
+			return nil, fmt.Errorf("failed to parse otel TLS CA file: %s", caFile)
+		}
+		tlsConfig.RootCAs = caPool
+	}
+
+	if certFile != "" && keyFile != "" {
+		clientCert, err := tls.LoadX509KeyPair(certFile, keyFile)
+		if err != nil {
+			return nil, fmt.Errorf("failed to load otel TLS client certificate: %w", err)
+		}
+		tlsConfig.Certificates = []tls.Certificate{clientCert}
+	}
+
+	return tlsConfig, nil
+}
+
+const (
+	compressionGzip = "gzip"
+
+	retryInitialInterval = 5 * time.Second
+	retryMaxInterval     = 30 * time.Second
+)